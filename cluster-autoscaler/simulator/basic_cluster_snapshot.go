@@ -0,0 +1,265 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// basicSnapshotData holds the actual node/pod state for a single layer of a
+// BasicClusterSnapshot.
+type basicSnapshotData struct {
+	nodeInfoMap map[string]*schedulernodeinfo.NodeInfo
+}
+
+func newBasicSnapshotData() *basicSnapshotData {
+	return &basicSnapshotData{
+		nodeInfoMap: make(map[string]*schedulernodeinfo.NodeInfo),
+	}
+}
+
+// clone returns a deep-enough copy of the data that mutating the copy never
+// affects the original. BasicClusterSnapshot relies on cloning the entire map
+// on Fork(), which is simple to reason about but expensive for large clusters -
+// DeltaClusterSnapshot exists to avoid that cost.
+func (data *basicSnapshotData) clone() *basicSnapshotData {
+	clone := newBasicSnapshotData()
+	for name, nodeInfo := range data.nodeInfoMap {
+		clone.nodeInfoMap[name] = nodeInfo.Clone()
+	}
+	return clone
+}
+
+func (data *basicSnapshotData) getNodeInfo(nodeName string) (*schedulernodeinfo.NodeInfo, error) {
+	nodeInfo, found := data.nodeInfoMap[nodeName]
+	if !found {
+		return nil, errNodeNotFound
+	}
+	return nodeInfo, nil
+}
+
+func (data *basicSnapshotData) listNodeInfos() []*schedulernodeinfo.NodeInfo {
+	nodeInfoList := make([]*schedulernodeinfo.NodeInfo, 0, len(data.nodeInfoMap))
+	for _, nodeInfo := range data.nodeInfoMap {
+		nodeInfoList = append(nodeInfoList, nodeInfo)
+	}
+	return nodeInfoList
+}
+
+func (data *basicSnapshotData) listPods() []*apiv1.Pod {
+	pods := make([]*apiv1.Pod, 0)
+	for _, nodeInfo := range data.nodeInfoMap {
+		pods = append(pods, nodeInfo.Pods()...)
+	}
+	return pods
+}
+
+func (data *basicSnapshotData) addNode(node *apiv1.Node) error {
+	if _, found := data.nodeInfoMap[node.Name]; found {
+		return fmt.Errorf("node %s already in snapshot", node.Name)
+	}
+	nodeInfo := schedulernodeinfo.NewNodeInfo()
+	if err := nodeInfo.SetNode(node); err != nil {
+		return fmt.Errorf("cannot set node %s in node info: %v", node.Name, err)
+	}
+	data.nodeInfoMap[node.Name] = nodeInfo
+	return nil
+}
+
+func (data *basicSnapshotData) removeNode(nodeName string) error {
+	if _, found := data.nodeInfoMap[nodeName]; !found {
+		return errNodeNotFound
+	}
+	delete(data.nodeInfoMap, nodeName)
+	return nil
+}
+
+func (data *basicSnapshotData) addPod(pod *apiv1.Pod, nodeName string) error {
+	nodeInfo, found := data.nodeInfoMap[nodeName]
+	if !found {
+		return errNodeNotFound
+	}
+	nodeInfo.AddPod(pod)
+	return nil
+}
+
+func (data *basicSnapshotData) removePod(namespace, podName, nodeName string, reason PodDisruptionReason) (*apiv1.Pod, error) {
+	nodeInfo, found := data.nodeInfoMap[nodeName]
+	if !found {
+		return nil, errNodeNotFound
+	}
+	for _, pod := range nodeInfo.Pods() {
+		if pod.Namespace == namespace && pod.Name == podName {
+			if err := nodeInfo.RemovePod(pod); err != nil {
+				return nil, err
+			}
+			if reason == "" {
+				return pod, nil
+			}
+			// Pods can be shared across fork layers (NodeInfo.Clone() only
+			// shallow-copies its pod slice), so stamp a copy rather than pod
+			// itself to avoid mutating state a Revert() is supposed to undo.
+			stamped := pod.DeepCopy()
+			SetDisruptionTargetCondition(stamped, reason)
+			return stamped, nil
+		}
+	}
+	return nil, fmt.Errorf("pod %s/%s not found on node %s", namespace, podName, nodeName)
+}
+
+// BasicClusterSnapshot is simple, reference implementation of ClusterSnapshot.
+// It is inefficient, but easy to follow, and is used to check correctness of
+// other (more complex) implementations via automated tests. It's also useful
+// for simple use-cases where performance is not critical.
+//
+// Fork/Commit/Revert form a stack of layers: layers[0] is the base state, and
+// every subsequent layer is a full clone of the one beneath it, cloned again
+// at the moment of forking.
+type BasicClusterSnapshot struct {
+	layers []*basicSnapshotData
+}
+
+// NewBasicClusterSnapshot creates an empty BasicClusterSnapshot.
+func NewBasicClusterSnapshot() *BasicClusterSnapshot {
+	snapshot := &BasicClusterSnapshot{}
+	snapshot.Clear()
+	return snapshot
+}
+
+func (snapshot *BasicClusterSnapshot) getInternalData() *basicSnapshotData {
+	return snapshot.layers[len(snapshot.layers)-1]
+}
+
+// AddNode adds node to the snapshot.
+func (snapshot *BasicClusterSnapshot) AddNode(node *apiv1.Node) error {
+	return snapshot.getInternalData().addNode(node)
+}
+
+// AddNodes adds nodes to the snapshot.
+func (snapshot *BasicClusterSnapshot) AddNodes(nodes []*apiv1.Node) error {
+	for _, node := range nodes {
+		if err := snapshot.AddNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveNode removes node from the snapshot.
+func (snapshot *BasicClusterSnapshot) RemoveNode(nodeName string) error {
+	return snapshot.getInternalData().removeNode(nodeName)
+}
+
+// AddPod adds pod to the snapshot and schedules it to given node.
+func (snapshot *BasicClusterSnapshot) AddPod(pod *apiv1.Pod, nodeName string) error {
+	return snapshot.getInternalData().addPod(pod, nodeName)
+}
+
+// RemovePod removes pod from the snapshot.
+func (snapshot *BasicClusterSnapshot) RemovePod(namespace string, podName string, nodeName string) error {
+	_, err := snapshot.getInternalData().removePod(namespace, podName, nodeName, "")
+	return err
+}
+
+// RemovePodWithReason removes pod from the snapshot and returns a copy of it
+// stamped with a DisruptionTarget condition carrying reason.
+func (snapshot *BasicClusterSnapshot) RemovePodWithReason(namespace string, podName string, nodeName string, reason PodDisruptionReason) (*apiv1.Pod, error) {
+	return snapshot.getInternalData().removePod(namespace, podName, nodeName, reason)
+}
+
+// Fork pushes a clone of the current top layer onto the layer stack. All
+// modifications can later be reverted to the moment of forking via Revert(),
+// or folded into the layer beneath via Commit().
+//
+// Fork/Commit/Revert form a stack: Fork() may be called again on an already
+// forked snapshot to nest another layer on top, letting callers explore
+// nested hypothetical scenarios (e.g. "if I add node A, and then also evict
+// pod P"). Every operation always sees the state of the top layer, which
+// starts out as a full copy of the layer beneath it.
+func (snapshot *BasicClusterSnapshot) Fork() error {
+	snapshot.layers = append(snapshot.layers, snapshot.getInternalData().clone())
+	return nil
+}
+
+// Revert pops the top layer off the stack, reverting to the state seen just
+// before the matching Fork(). It's a no-op if there's no fork in progress.
+func (snapshot *BasicClusterSnapshot) Revert() error {
+	if len(snapshot.layers) == 1 {
+		return nil
+	}
+	snapshot.layers = snapshot.layers[:len(snapshot.layers)-1]
+	return nil
+}
+
+// Commit discards the layer beneath the top of the stack, keeping the top
+// layer (which already reflects every change made since the matching
+// Fork()) as the new top. It's a no-op if there's no fork in progress.
+func (snapshot *BasicClusterSnapshot) Commit() error {
+	if len(snapshot.layers) == 1 {
+		return nil
+	}
+	top := len(snapshot.layers) - 1
+	snapshot.layers[top-1] = snapshot.layers[top]
+	snapshot.layers = snapshot.layers[:top]
+	return nil
+}
+
+// Clear resets cluster snapshot to empty, unforked state.
+func (snapshot *BasicClusterSnapshot) Clear() {
+	snapshot.layers = []*basicSnapshotData{newBasicSnapshotData()}
+}
+
+// implementation of NodeInfoLister
+
+type basicClusterSnapshotNodeLister BasicClusterSnapshot
+
+// NodeInfos exposes snapshot as NodeInfoLister.
+func (snapshot *BasicClusterSnapshot) NodeInfos() NodeInfoLister {
+	return (*basicClusterSnapshotNodeLister)(snapshot)
+}
+
+func (snapshot *basicClusterSnapshotNodeLister) List() ([]*schedulernodeinfo.NodeInfo, error) {
+	return (*BasicClusterSnapshot)(snapshot).getInternalData().listNodeInfos(), nil
+}
+
+func (snapshot *basicClusterSnapshotNodeLister) Get(nodeName string) (*schedulernodeinfo.NodeInfo, error) {
+	return (*BasicClusterSnapshot)(snapshot).getInternalData().getNodeInfo(nodeName)
+}
+
+// implementation of PodLister
+
+type basicClusterSnapshotPodLister BasicClusterSnapshot
+
+// Pods exposes snapshot as PodLister.
+func (snapshot *BasicClusterSnapshot) Pods() PodLister {
+	return (*basicClusterSnapshotPodLister)(snapshot)
+}
+
+func (snapshot *basicClusterSnapshotPodLister) List(selector labels.Selector) ([]*apiv1.Pod, error) {
+	pods := (*BasicClusterSnapshot)(snapshot).getInternalData().listPods()
+	result := make([]*apiv1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
+}
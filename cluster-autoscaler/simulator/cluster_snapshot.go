@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// errNodeNotFound is returned whenever ClusterSnapshot is asked about a node that
+// it doesn't know about.
+var errNodeNotFound = errors.New("node not found")
+
+// NodeInfoLister lists NodeInfos for all nodes known to a ClusterSnapshot.
+type NodeInfoLister interface {
+	List() ([]*schedulernodeinfo.NodeInfo, error)
+	Get(nodeName string) (*schedulernodeinfo.NodeInfo, error)
+}
+
+// PodLister lists pods known to a ClusterSnapshot.
+type PodLister interface {
+	List(selector labels.Selector) ([]*apiv1.Pod, error)
+}
+
+// ClusterSnapshot is abstraction of cluster state used for predicate simulations.
+// It exposes mutation methods (Add/RemoveNode, Add/RemovePod) as well as a pair
+// of listers that mirror the scheduler's view of the cluster, so that the same
+// predicate/extender code used against a live cluster can run against a
+// simulated one. Fork/Commit/Revert let callers try out a hypothetical change
+// and either fold it into the base state or discard it.
+type ClusterSnapshot interface {
+	// AddNode adds node to the snapshot.
+	AddNode(node *apiv1.Node) error
+	// AddNodes adds nodes to the snapshot.
+	AddNodes(nodes []*apiv1.Node) error
+	// RemoveNode removes node from the snapshot.
+	RemoveNode(nodeName string) error
+	// AddPod adds pod to the snapshot and schedules it to given node.
+	AddPod(pod *apiv1.Pod, nodeName string) error
+	// RemovePod removes pod from the snapshot.
+	RemovePod(namespace string, podName string, nodeName string) error
+	// RemovePodWithReason removes pod from the snapshot and returns a copy of
+	// it stamped with a DisruptionTarget condition carrying reason, so that
+	// the caller can forward that copy to the real Eviction API. The copy is
+	// independent of whatever is stored in any fork layer, so stamping it
+	// never mutates base (pre-fork) state.
+	RemovePodWithReason(namespace string, podName string, nodeName string, reason PodDisruptionReason) (*apiv1.Pod, error)
+	// NodeInfos returns NodeInfoLister that can be used to list/get nodes in the snapshot.
+	NodeInfos() NodeInfoLister
+	// Pods returns PodLister that can be used to list/get pods in the snapshot.
+	Pods() PodLister
+	// Fork creates a fork of snapshot state. All modifications can later be reverted
+	// to moment of forking via Revert(). Use Commit() to merge the fork into the
+	// previous state permanently.
+	//
+	// Fork/Commit/Revert form a stack: Fork() may be called again on an
+	// already forked snapshot to nest another layer on top, and every
+	// operation always sees the merged view of the whole stack.
+	Fork() error
+	// Revert discards the top layer of the fork stack, reverting to the state
+	// seen just before the matching Fork().
+	Revert() error
+	// Commit folds the top layer of the fork stack into the layer beneath it.
+	Commit() error
+	// Clear resets cluster snapshot to empty, unforked state.
+	Clear()
+}
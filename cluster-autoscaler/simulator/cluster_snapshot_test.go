@@ -350,6 +350,177 @@ func TestForking(t *testing.T) {
 	}
 }
 
+func TestRemovePodWithReason(t *testing.T) {
+	for name, snapshotFactory := range snapshots {
+		t.Run(fmt.Sprintf("%s: remove pod with reason stamps a copy, leaving base data untouched", name),
+			func(t *testing.T) {
+				node := BuildTestNode("node", 10, 100)
+				pod := BuildTestPod("pod", 1, 1)
+				pod.Spec.NodeName = node.Name
+
+				snapshot := snapshotFactory()
+				err := snapshot.AddNode(node)
+				assert.NoError(t, err)
+				err = snapshot.AddPod(pod, node.Name)
+				assert.NoError(t, err)
+
+				err = snapshot.Fork()
+				assert.NoError(t, err)
+
+				stamped, err := snapshot.RemovePodWithReason(pod.Namespace, pod.Name, node.Name, TerminationByClusterAutoscaler)
+				assert.NoError(t, err)
+				assert.Equal(t, apiv1.ConditionTrue, findDisruptionTargetCondition(stamped).Status)
+				assert.Equal(t, string(TerminationByClusterAutoscaler), findDisruptionTargetCondition(stamped).Reason)
+				// pod itself must be untouched - it (or a shared NodeInfo pod
+				// slice pointing to it) may still be reachable from the base
+				// layer that Revert() is about to restore.
+				assert.Nil(t, findDisruptionTargetCondition(pod))
+
+				err = snapshot.Revert()
+				assert.NoError(t, err)
+
+				pods, err := snapshot.Pods().List(labels.Everything())
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []*apiv1.Pod{pod}, pods)
+				assert.Nil(t, findDisruptionTargetCondition(pods[0]))
+			})
+	}
+}
+
+func findDisruptionTargetCondition(pod *apiv1.Pod) *apiv1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == PodDisruptionConditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestCommitRemoveThenReAddNode(t *testing.T) {
+	for name, snapshotFactory := range snapshots {
+		t.Run(fmt.Sprintf("%s: commit remove then re-add node", name),
+			func(t *testing.T) {
+				node := BuildTestNode("node", 10, 100)
+
+				snapshot := snapshotFactory()
+				err := snapshot.AddNode(node)
+				assert.NoError(t, err)
+
+				err = snapshot.Fork()
+				assert.NoError(t, err)
+				err = snapshot.RemoveNode(node.Name)
+				assert.NoError(t, err)
+				err = snapshot.Commit()
+				assert.NoError(t, err)
+
+				err = snapshot.Fork()
+				assert.NoError(t, err)
+				err = snapshot.AddNode(node)
+				assert.NoError(t, err)
+				err = snapshot.Commit()
+				assert.NoError(t, err)
+
+				nodes, err := snapshot.NodeInfos().List()
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []*apiv1.Node{node}, extractNodes(nodes))
+
+				_, err = snapshot.NodeInfos().Get(node.Name)
+				assert.NoError(t, err)
+			})
+	}
+}
+
+func TestForkStack(t *testing.T) {
+	const maxDepth = 3
+
+	for name, snapshotFactory := range snapshots {
+		for depth := 0; depth <= maxDepth; depth++ {
+			t.Run(fmt.Sprintf("%s: stacked fork depth %d", name, depth),
+				func(t *testing.T) {
+					snapshot := snapshotFactory()
+					base := BuildTestNode("base", 10, 100)
+					err := snapshot.AddNode(base)
+					assert.NoError(t, err)
+
+					present := []*apiv1.Node{base}
+					for level := 0; level < depth; level++ {
+						err = snapshot.Fork()
+						assert.NoError(t, err)
+
+						added := BuildTestNode(fmt.Sprintf("added-%d", level), 10, 100)
+						err = snapshot.AddNode(added)
+						assert.NoError(t, err)
+						present = append(present, added)
+
+						if level%2 == 1 {
+							// Every other level also removes a node added a level below,
+							// to exercise add/remove interleaved across the stack.
+							removed := present[len(present)-2]
+							err = snapshot.RemoveNode(removed.Name)
+							assert.NoError(t, err)
+							present = append(present[:len(present)-2], present[len(present)-1])
+						}
+					}
+
+					// Every level should see the merged view of the whole stack.
+					nodes, err := snapshot.NodeInfos().List()
+					assert.NoError(t, err)
+					assert.ElementsMatch(t, nodeNames(present), nodeInfoNames(nodes))
+
+					// Unwind the stack one Revert() at a time; once every layer has
+					// been popped, only the base node should be left.
+					for level := depth - 1; level >= 0; level-- {
+						err = snapshot.Revert()
+						assert.NoError(t, err)
+					}
+
+					nodes, err = snapshot.NodeInfos().List()
+					assert.NoError(t, err)
+					assert.ElementsMatch(t, nodeNames([]*apiv1.Node{base}), nodeInfoNames(nodes))
+				})
+
+			t.Run(fmt.Sprintf("%s: stacked commit depth %d", name, depth),
+				func(t *testing.T) {
+					snapshot := snapshotFactory()
+					base := BuildTestNode("base", 10, 100)
+					err := snapshot.AddNode(base)
+					assert.NoError(t, err)
+
+					present := []*apiv1.Node{base}
+					for level := 0; level < depth; level++ {
+						err = snapshot.Fork()
+						assert.NoError(t, err)
+
+						added := BuildTestNode(fmt.Sprintf("added-%d", level), 10, 100)
+						err = snapshot.AddNode(added)
+						assert.NoError(t, err)
+						present = append(present, added)
+					}
+
+					// Folding every layer back down should keep all additions, since
+					// Commit() never discards modifications - only Revert() does.
+					for level := 0; level < depth; level++ {
+						err = snapshot.Commit()
+						assert.NoError(t, err)
+					}
+
+					nodes, err := snapshot.NodeInfos().List()
+					assert.NoError(t, err)
+					assert.ElementsMatch(t, nodeNames(present), nodeInfoNames(nodes))
+
+					// With no fork left, Revert() and Commit() are no-ops.
+					err = snapshot.Revert()
+					assert.NoError(t, err)
+					err = snapshot.Commit()
+					assert.NoError(t, err)
+					nodes, err = snapshot.NodeInfos().List()
+					assert.NoError(t, err)
+					assert.ElementsMatch(t, nodeNames(present), nodeInfoNames(nodes))
+				})
+		}
+	}
+}
+
 func TestNode404(t *testing.T) {
 	// Anything and everything that returns errNodeNotFound should be tested here.
 	ops := []struct {
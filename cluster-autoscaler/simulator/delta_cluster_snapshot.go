@@ -0,0 +1,326 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// deltaSnapshotData tracks a base set of NodeInfos plus the additions,
+// modifications and deletions made on top of it, so that Fork() doesn't have
+// to pay the cost of copying every NodeInfo in the cluster - only the ones
+// actually touched while forked.
+type deltaSnapshotData struct {
+	baseData *deltaSnapshotData
+
+	addedNodeInfoMap    map[string]*schedulernodeinfo.NodeInfo
+	modifiedNodeInfoMap map[string]*schedulernodeinfo.NodeInfo
+	deletedNodeNames    map[string]bool
+}
+
+func newDeltaSnapshotData() *deltaSnapshotData {
+	return &deltaSnapshotData{
+		addedNodeInfoMap:    make(map[string]*schedulernodeinfo.NodeInfo),
+		modifiedNodeInfoMap: make(map[string]*schedulernodeinfo.NodeInfo),
+		deletedNodeNames:    make(map[string]bool),
+	}
+}
+
+// fork returns a new layer sitting on top of data. Only the delta is
+// allocated - the base layer is shared, not copied.
+func (data *deltaSnapshotData) fork() *deltaSnapshotData {
+	forked := newDeltaSnapshotData()
+	forked.baseData = data
+	return forked
+}
+
+func (data *deltaSnapshotData) getNodeInfo(nodeName string) (*schedulernodeinfo.NodeInfo, error) {
+	if data.deletedNodeNames[nodeName] {
+		return nil, errNodeNotFound
+	}
+	if nodeInfo, found := data.addedNodeInfoMap[nodeName]; found {
+		return nodeInfo, nil
+	}
+	if nodeInfo, found := data.modifiedNodeInfoMap[nodeName]; found {
+		return nodeInfo, nil
+	}
+	if data.baseData != nil {
+		return data.baseData.getNodeInfo(nodeName)
+	}
+	return nil, errNodeNotFound
+}
+
+// listNodeInfos walks the whole stack of layers to collect every node name
+// ever mentioned by any of them, then resolves each one through
+// getNodeInfo(), so that a node added at the bottom of the stack and deleted
+// somewhere in the middle stays hidden regardless of how many layers are
+// stacked on top. This full-stack merge is what makes the state seen by
+// callers always the merged view of the entire fork chain, not just of the
+// top layer.
+func (data *deltaSnapshotData) listNodeInfos() []*schedulernodeinfo.NodeInfo {
+	names := make(map[string]bool)
+	for layer := data; layer != nil; layer = layer.baseData {
+		for name := range layer.addedNodeInfoMap {
+			names[name] = true
+		}
+		for name := range layer.modifiedNodeInfoMap {
+			names[name] = true
+		}
+	}
+	result := make([]*schedulernodeinfo.NodeInfo, 0, len(names))
+	for name := range names {
+		if nodeInfo, err := data.getNodeInfo(name); err == nil {
+			result = append(result, nodeInfo)
+		}
+	}
+	return result
+}
+
+func (data *deltaSnapshotData) listPods() []*apiv1.Pod {
+	pods := make([]*apiv1.Pod, 0)
+	for _, nodeInfo := range data.listNodeInfos() {
+		pods = append(pods, nodeInfo.Pods()...)
+	}
+	return pods
+}
+
+func (data *deltaSnapshotData) addNode(node *apiv1.Node) error {
+	if _, err := data.getNodeInfo(node.Name); err == nil {
+		return fmt.Errorf("node %s already in snapshot", node.Name)
+	}
+	nodeInfo := schedulernodeinfo.NewNodeInfo()
+	if err := nodeInfo.SetNode(node); err != nil {
+		return fmt.Errorf("cannot set node %s in node info: %v", node.Name, err)
+	}
+	delete(data.deletedNodeNames, node.Name)
+	data.addedNodeInfoMap[node.Name] = nodeInfo
+	return nil
+}
+
+func (data *deltaSnapshotData) removeNode(nodeName string) error {
+	if _, err := data.getNodeInfo(nodeName); err != nil {
+		return err
+	}
+	delete(data.addedNodeInfoMap, nodeName)
+	delete(data.modifiedNodeInfoMap, nodeName)
+	data.deletedNodeNames[nodeName] = true
+	return nil
+}
+
+func (data *deltaSnapshotData) addPod(pod *apiv1.Pod, nodeName string) error {
+	nodeInfo, err := data.getNodeInfo(nodeName)
+	if err != nil {
+		return err
+	}
+	nodeInfo = nodeInfo.Clone()
+	nodeInfo.AddPod(pod)
+	data.setNodeInfo(nodeName, nodeInfo)
+	return nil
+}
+
+func (data *deltaSnapshotData) removePod(namespace, podName, nodeName string, reason PodDisruptionReason) (*apiv1.Pod, error) {
+	nodeInfo, err := data.getNodeInfo(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	var toRemove *apiv1.Pod
+	for _, pod := range nodeInfo.Pods() {
+		if pod.Namespace == namespace && pod.Name == podName {
+			toRemove = pod
+			break
+		}
+	}
+	if toRemove == nil {
+		return nil, fmt.Errorf("pod %s/%s not found on node %s", namespace, podName, nodeName)
+	}
+	nodeInfo = nodeInfo.Clone()
+	if err := nodeInfo.RemovePod(toRemove); err != nil {
+		return nil, err
+	}
+	data.setNodeInfo(nodeName, nodeInfo)
+
+	if reason == "" {
+		return toRemove, nil
+	}
+	// toRemove may be the very same pod object a lower (base) layer still
+	// points to - NodeInfo.Clone() only shallow-copies its pod slice - so
+	// stamp a copy rather than toRemove itself to avoid mutating state a
+	// Revert() is supposed to undo.
+	stamped := toRemove.DeepCopy()
+	SetDisruptionTargetCondition(stamped, reason)
+	return stamped, nil
+}
+
+// setNodeInfo records nodeInfo as the current state of nodeName in this layer,
+// as either an addition (if the node was added in this very layer) or a
+// modification (if it pre-dates this layer).
+func (data *deltaSnapshotData) setNodeInfo(nodeName string, nodeInfo *schedulernodeinfo.NodeInfo) {
+	if _, found := data.addedNodeInfoMap[nodeName]; found {
+		data.addedNodeInfoMap[nodeName] = nodeInfo
+		return
+	}
+	data.modifiedNodeInfoMap[nodeName] = nodeInfo
+}
+
+// DeltaClusterSnapshot is an implementation of ClusterSnapshot optimized for
+// typical autoscaler usage: Fork() is called often with small modifications on
+// top, so it's much cheaper to track just those modifications (a "delta") than
+// to copy the whole cluster state as BasicClusterSnapshot does.
+type DeltaClusterSnapshot struct {
+	data *deltaSnapshotData
+}
+
+// NewDeltaClusterSnapshot creates an empty DeltaClusterSnapshot.
+func NewDeltaClusterSnapshot() *DeltaClusterSnapshot {
+	snapshot := &DeltaClusterSnapshot{}
+	snapshot.Clear()
+	return snapshot
+}
+
+// AddNode adds node to the snapshot.
+func (snapshot *DeltaClusterSnapshot) AddNode(node *apiv1.Node) error {
+	return snapshot.data.addNode(node)
+}
+
+// AddNodes adds nodes to the snapshot.
+func (snapshot *DeltaClusterSnapshot) AddNodes(nodes []*apiv1.Node) error {
+	for _, node := range nodes {
+		if err := snapshot.AddNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveNode removes node from the snapshot.
+func (snapshot *DeltaClusterSnapshot) RemoveNode(nodeName string) error {
+	return snapshot.data.removeNode(nodeName)
+}
+
+// AddPod adds pod to the snapshot and schedules it to given node.
+func (snapshot *DeltaClusterSnapshot) AddPod(pod *apiv1.Pod, nodeName string) error {
+	return snapshot.data.addPod(pod, nodeName)
+}
+
+// RemovePod removes pod from the snapshot.
+func (snapshot *DeltaClusterSnapshot) RemovePod(namespace string, podName string, nodeName string) error {
+	_, err := snapshot.data.removePod(namespace, podName, nodeName, "")
+	return err
+}
+
+// RemovePodWithReason removes pod from the snapshot and returns a copy of it
+// stamped with a DisruptionTarget condition carrying reason.
+func (snapshot *DeltaClusterSnapshot) RemovePodWithReason(namespace string, podName string, nodeName string, reason PodDisruptionReason) (*apiv1.Pod, error) {
+	return snapshot.data.removePod(namespace, podName, nodeName, reason)
+}
+
+// Fork creates a new delta layer on top of the current one. All modifications
+// can later be reverted to the moment of forking via Revert(), or folded into
+// the layer beneath via Commit().
+//
+// Fork/Commit/Revert form a stack: Fork() can be called again on an already
+// forked snapshot to push another layer, letting callers explore nested
+// hypothetical scenarios (e.g. "if I add node A, and then also evict pod P").
+// Every operation always sees the merged view of the entire stack, and
+// Commit/Revert only ever pop the top layer (Commit first folds its changes
+// into the layer beneath it).
+func (snapshot *DeltaClusterSnapshot) Fork() error {
+	snapshot.data = snapshot.data.fork()
+	return nil
+}
+
+// Revert discards the top delta layer, reverting to the state seen just
+// before the matching Fork(). It's a no-op if there's no fork in progress.
+func (snapshot *DeltaClusterSnapshot) Revert() error {
+	if snapshot.data.baseData == nil {
+		return nil
+	}
+	snapshot.data = snapshot.data.baseData
+	return nil
+}
+
+// Commit folds the top delta layer into the layer beneath it and forgets
+// about the fork. It's a no-op if there's no fork in progress.
+func (snapshot *DeltaClusterSnapshot) Commit() error {
+	if snapshot.data.baseData == nil {
+		// No fork in progress, Commit() is a no-op.
+		return nil
+	}
+	delta := snapshot.data
+	base := delta.baseData
+	for name, nodeInfo := range delta.addedNodeInfoMap {
+		delete(base.deletedNodeNames, name)
+		base.addedNodeInfoMap[name] = nodeInfo
+	}
+	for name, nodeInfo := range delta.modifiedNodeInfoMap {
+		delete(base.deletedNodeNames, name)
+		base.setNodeInfo(name, nodeInfo)
+	}
+	for name := range delta.deletedNodeNames {
+		delete(base.addedNodeInfoMap, name)
+		delete(base.modifiedNodeInfoMap, name)
+		base.deletedNodeNames[name] = true
+	}
+	snapshot.data = base
+	return nil
+}
+
+// Clear resets cluster snapshot to empty, unforked state.
+func (snapshot *DeltaClusterSnapshot) Clear() {
+	snapshot.data = newDeltaSnapshotData()
+}
+
+// implementation of NodeInfoLister
+
+type deltaClusterSnapshotNodeLister DeltaClusterSnapshot
+
+// NodeInfos exposes snapshot as NodeInfoLister.
+func (snapshot *DeltaClusterSnapshot) NodeInfos() NodeInfoLister {
+	return (*deltaClusterSnapshotNodeLister)(snapshot)
+}
+
+func (snapshot *deltaClusterSnapshotNodeLister) List() ([]*schedulernodeinfo.NodeInfo, error) {
+	return (*DeltaClusterSnapshot)(snapshot).data.listNodeInfos(), nil
+}
+
+func (snapshot *deltaClusterSnapshotNodeLister) Get(nodeName string) (*schedulernodeinfo.NodeInfo, error) {
+	return (*DeltaClusterSnapshot)(snapshot).data.getNodeInfo(nodeName)
+}
+
+// implementation of PodLister
+
+type deltaClusterSnapshotPodLister DeltaClusterSnapshot
+
+// Pods exposes snapshot as PodLister.
+func (snapshot *DeltaClusterSnapshot) Pods() PodLister {
+	return (*deltaClusterSnapshotPodLister)(snapshot)
+}
+
+func (snapshot *deltaClusterSnapshotPodLister) List(selector labels.Selector) ([]*apiv1.Pod, error) {
+	pods := (*DeltaClusterSnapshot)(snapshot).data.listPods()
+	result := make([]*apiv1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.Labels)) {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// PodDisruptionReason identifies why a pod's DisruptionTarget condition was
+// set, mirroring the reasons kube-scheduler preemption and the eviction API
+// use so downstream controllers (Job, StatefulSet, custom operators) can tell
+// autoscaler-initiated terminations apart from application crashes.
+type PodDisruptionReason string
+
+const (
+	// TerminationByClusterAutoscaler marks a pod removed as part of a plain
+	// scale-down decision.
+	TerminationByClusterAutoscaler PodDisruptionReason = "TerminationByClusterAutoscaler"
+	// PreemptionByKubeScheduler marks a pod removed to make room for a higher
+	// priority pod, mirroring the reason kube-scheduler itself uses.
+	PreemptionByKubeScheduler PodDisruptionReason = "PreemptionByKubeScheduler"
+	// EvictionByEvictionAPI marks a pod removed through the eviction API.
+	EvictionByEvictionAPI PodDisruptionReason = "EvictionByEvictionAPI"
+)
+
+// PodDisruptionConditionType is the pod condition type stamped on pods that
+// are about to be terminated, matching the upstream "DisruptionTarget"
+// condition used by kube-scheduler preemption and the eviction API.
+const PodDisruptionConditionType apiv1.PodConditionType = "DisruptionTarget"
+
+// SetDisruptionTargetCondition stamps pod with a DisruptionTarget condition
+// carrying reason, replacing any condition of that type already present.
+func SetDisruptionTargetCondition(pod *apiv1.Pod, reason PodDisruptionReason) {
+	condition := apiv1.PodCondition{
+		Type:    PodDisruptionConditionType,
+		Status:  apiv1.ConditionTrue,
+		Reason:  string(reason),
+		Message: fmt.Sprintf("Pod is being disrupted by the cluster autoscaler: %s", reason),
+	}
+	for i, existing := range pod.Status.Conditions {
+		if existing.Type == PodDisruptionConditionType {
+			pod.Status.Conditions[i] = condition
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+}
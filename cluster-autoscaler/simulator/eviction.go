@@ -0,0 +1,43 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EvictPod stamps pod with a DisruptionTarget condition for reason, patches
+// that onto the live pod so watching controllers see why it was targeted,
+// and then issues the actual Eviction API call against client.
+func EvictPod(ctx context.Context, client kubernetes.Interface, pod *apiv1.Pod, reason PodDisruptionReason) error {
+	patched := pod.DeepCopy()
+	SetDisruptionTargetCondition(patched, reason)
+	if _, err := client.CoreV1().Pods(patched.Namespace).UpdateStatus(ctx, patched, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to patch DisruptionTarget condition on pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+	}
+	return client.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, eviction)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// PredicateError is returned whenever a pod doesn't fit a node, either
+// because of in-tree predicates or because a SchedulerExtender rejected it.
+type PredicateError struct {
+	reasons []string
+}
+
+// Error implements the error interface.
+func (pe *PredicateError) Error() string {
+	return fmt.Sprintf("pod didn't fit: %v", pe.reasons)
+}
+
+// PredicateChecker checks whether a given pod can be placed on a given node,
+// taking both in-tree resource-fit predicates and any configured
+// SchedulerExtenders into account.
+type PredicateChecker struct {
+	extenders []SchedulerExtender
+}
+
+// NewPredicateChecker builds a PredicateChecker that additionally consults
+// extenders (in order) before declaring a node a fit.
+func NewPredicateChecker(extenders []SchedulerExtender) *PredicateChecker {
+	return &PredicateChecker{extenders: extenders}
+}
+
+// FitsAnyNode checks whether the given pod can be scheduled on any of
+// candidateNodes, looking each one up in clusterSnapshot and running the
+// in-tree resource fit predicate and every configured extender against it in
+// turn, and returning the first node that passes both. Nodes tracked by
+// clusterSnapshot but not listed in candidateNodes are not checked.
+func (p *PredicateChecker) FitsAnyNode(clusterSnapshot ClusterSnapshot, pod *apiv1.Pod, candidateNodes []*apiv1.Node) (*apiv1.Node, error) {
+	var lastErr error
+	for _, node := range candidateNodes {
+		if err := p.CheckPredicates(clusterSnapshot, pod, node.Name); err != nil {
+			lastErr = err
+			continue
+		}
+		return node, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, &PredicateError{reasons: []string{"no nodes to check"}}
+}
+
+// CheckPredicates checks whether pod can be scheduled onto the node currently
+// tracked by clusterSnapshot under nodeName: first the in-tree resource fit
+// predicate, then every configured extender, in order.
+func (p *PredicateChecker) CheckPredicates(clusterSnapshot ClusterSnapshot, pod *apiv1.Pod, nodeName string) error {
+	nodeInfo, err := clusterSnapshot.NodeInfos().Get(nodeName)
+	if err != nil {
+		return &PredicateError{reasons: []string{err.Error()}}
+	}
+	if !nodeFitsResources(nodeInfo, pod) {
+		return &PredicateError{reasons: []string{fmt.Sprintf("node %s didn't have enough allocatable resources for pod %s/%s", nodeName, pod.Namespace, pod.Name)}}
+	}
+
+	node := nodeInfo.Node()
+	for _, extender := range p.extenders {
+		filtered, failedNodes, err := extender.Filter(pod, []*apiv1.Node{node})
+		if err != nil {
+			if extender.IsIgnorable() {
+				continue
+			}
+			return &PredicateError{reasons: []string{fmt.Sprintf("extender %s error: %v", extender.Name(), err)}}
+		}
+		if len(filtered) == 0 {
+			return &PredicateError{reasons: []string{failedNodes[node.Name]}}
+		}
+	}
+	return nil
+}
+
+// nodeFitsResources reports whether pod's resource requests fit in whatever
+// of the node's allocatable capacity isn't already requested by the pods
+// nodeInfo already carries.
+func nodeFitsResources(nodeInfo *schedulernodeinfo.NodeInfo, pod *apiv1.Pod) bool {
+	allocatable := nodeInfo.Node().Status.Allocatable
+	used := sumPodRequests(nodeInfo.Pods())
+	requested := sumPodRequests([]*apiv1.Pod{pod})
+
+	for name, reqQty := range requested {
+		allocQty, found := allocatable[name]
+		if !found {
+			return false
+		}
+		remaining := allocQty.DeepCopy()
+		if usedQty, found := used[name]; found {
+			remaining.Sub(usedQty)
+		}
+		if remaining.Cmp(reqQty) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sumPodRequests adds up the container resource requests of every pod in pods.
+func sumPodRequests(pods []*apiv1.Pod) apiv1.ResourceList {
+	total := apiv1.ResourceList{}
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				if existing, found := total[name]; found {
+					existing.Add(quantity)
+					total[name] = existing
+				} else {
+					total[name] = quantity.DeepCopy()
+				}
+			}
+		}
+	}
+	return total
+}
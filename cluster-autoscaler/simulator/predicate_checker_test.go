@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExtender struct {
+	allow     map[string]bool
+	ignorable bool
+}
+
+func (f *fakeExtender) Name() string       { return "fake" }
+func (f *fakeExtender) IsIgnorable() bool  { return f.ignorable }
+func (f *fakeExtender) SupportsBind() bool { return false }
+func (f *fakeExtender) Bind(*apiv1.Pod, *apiv1.Node) error {
+	return fmt.Errorf("bind not supported")
+}
+
+func (f *fakeExtender) Filter(pod *apiv1.Pod, nodes []*apiv1.Node) ([]*apiv1.Node, FailedNodesMap, error) {
+	filtered := []*apiv1.Node{}
+	failed := FailedNodesMap{}
+	for _, node := range nodes {
+		if f.allow[node.Name] {
+			filtered = append(filtered, node)
+		} else {
+			failed[node.Name] = "rejected by fake extender"
+		}
+	}
+	return filtered, failed, nil
+}
+
+func TestCheckPredicatesWithExtender(t *testing.T) {
+	node := BuildTestNode("node", 10, 100)
+	pod := BuildTestPod("pod", 1, 1)
+
+	snapshot := NewBasicClusterSnapshot()
+	assert.NoError(t, snapshot.AddNode(node))
+
+	allowingChecker := NewPredicateChecker([]SchedulerExtender{&fakeExtender{allow: map[string]bool{"node": true}}})
+	assert.NoError(t, allowingChecker.CheckPredicates(snapshot, pod, "node"))
+
+	rejectingChecker := NewPredicateChecker([]SchedulerExtender{&fakeExtender{allow: map[string]bool{}}})
+	err := rejectingChecker.CheckPredicates(snapshot, pod, "node")
+	assert.Error(t, err)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// FailedNodesMap maps a node name to the reason an extender rejected it.
+// It mirrors the shape kube-scheduler's HTTP extender protocol returns
+// alongside the list of nodes that did pass the filter.
+type FailedNodesMap map[string]string
+
+// SchedulerExtender is consulted, in addition to in-tree predicates, whenever
+// ClusterSnapshot-based code needs to know whether a pod fits on a node. It
+// lets operators running a custom scheduler extender (e.g. for GPU or
+// topology-aware placement) get scale-up/scale-down decisions that agree with
+// what the real scheduler would do.
+type SchedulerExtender interface {
+	// Name returns a human-readable extender name, used in logs and errors.
+	Name() string
+	// IsIgnorable reports whether a failure to reach this extender should be
+	// treated as "no opinion" rather than aborting the simulation.
+	IsIgnorable() bool
+	// Filter returns the subset of nodes that the extender considers the pod
+	// able to run on, plus the reason every rejected node was filtered out.
+	Filter(pod *apiv1.Pod, nodes []*apiv1.Node) (filtered []*apiv1.Node, failedNodes FailedNodesMap, err error)
+	// SupportsBind reports whether the extender wants to be called on Bind.
+	SupportsBind() bool
+	// Bind notifies the extender that pod has been bound to node. Only called
+	// when SupportsBind() is true.
+	Bind(pod *apiv1.Pod, node *apiv1.Node) error
+}
+
+// ExtenderConfig describes how to reach and talk to a single HTTP scheduler
+// extender. It follows the same shape as upstream kube-scheduler's
+// schedulerapi.ExtenderConfig, so that an autoscaler operator can point it at
+// the same extender(s) their scheduler already uses.
+type ExtenderConfig struct {
+	// URLPrefix at which the extender is listening, e.g. "http://extender:80/".
+	URLPrefix string
+	// FilterVerb is appended to URLPrefix for filter calls, e.g. "filter".
+	// Empty means the extender doesn't implement filtering.
+	FilterVerb string
+	// PrioritizeVerb is appended to URLPrefix for prioritize calls.
+	// Empty means the extender doesn't implement prioritizing.
+	PrioritizeVerb string
+	// BindVerb is appended to URLPrefix for bind calls. Empty means the
+	// extender doesn't want to be notified about bindings.
+	BindVerb string
+	// NodeCacheCapable means nodes are passed to the extender by name only;
+	// when false, full Node objects are sent on every call.
+	NodeCacheCapable bool
+	// Ignorable marks the extender optional: if it cannot be reached, its
+	// opinion is skipped instead of failing the whole simulation.
+	Ignorable bool
+	// HTTPTimeout bounds every call made to the extender.
+	HTTPTimeout time.Duration
+}
+
+type extenderFilterRequest struct {
+	Pod       *apiv1.Pod      `json:"pod"`
+	Nodes     *apiv1.NodeList `json:"nodes,omitempty"`
+	NodeNames *[]string       `json:"nodenames,omitempty"`
+}
+
+type extenderFilterResult struct {
+	Nodes       *apiv1.NodeList `json:"nodes,omitempty"`
+	NodeNames   *[]string       `json:"nodenames,omitempty"`
+	FailedNodes FailedNodesMap  `json:"failedNodes,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+type extenderBindingRequest struct {
+	PodName      string `json:"podName"`
+	PodNamespace string `json:"podNamespace"`
+	Node         string `json:"node"`
+}
+
+type extenderBindingResult struct {
+	Error string `json:"error,omitempty"`
+}
+
+// httpExtender talks the same JSON-over-HTTP protocol kube-scheduler uses for
+// its extenders.
+type httpExtender struct {
+	config     ExtenderConfig
+	httpClient *http.Client
+}
+
+// NewHTTPExtender builds a SchedulerExtender that calls out to an HTTP
+// scheduler extender described by cfg.
+func NewHTTPExtender(cfg ExtenderConfig) SchedulerExtender {
+	return &httpExtender{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.HTTPTimeout},
+	}
+}
+
+func (e *httpExtender) Name() string {
+	return e.config.URLPrefix
+}
+
+func (e *httpExtender) IsIgnorable() bool {
+	return e.config.Ignorable
+}
+
+func (e *httpExtender) SupportsBind() bool {
+	return e.config.BindVerb != ""
+}
+
+func (e *httpExtender) Filter(pod *apiv1.Pod, nodes []*apiv1.Node) ([]*apiv1.Node, FailedNodesMap, error) {
+	if e.config.FilterVerb == "" {
+		return nodes, nil, nil
+	}
+
+	req := &extenderFilterRequest{Pod: pod}
+	if e.config.NodeCacheCapable {
+		names := make([]string, len(nodes))
+		for i, node := range nodes {
+			names[i] = node.Name
+		}
+		req.NodeNames = &names
+	} else {
+		req.Nodes = &apiv1.NodeList{Items: make([]apiv1.Node, len(nodes))}
+		for i, node := range nodes {
+			req.Nodes.Items[i] = *node
+		}
+	}
+
+	result := &extenderFilterResult{}
+	if err := e.send(e.config.FilterVerb, req, result); err != nil {
+		return nil, nil, err
+	}
+	if result.Error != "" {
+		return nil, nil, fmt.Errorf("extender %s returned an error: %s", e.Name(), result.Error)
+	}
+
+	if result.NodeNames != nil {
+		byName := make(map[string]*apiv1.Node, len(nodes))
+		for _, node := range nodes {
+			byName[node.Name] = node
+		}
+		filtered := make([]*apiv1.Node, 0, len(*result.NodeNames))
+		for _, name := range *result.NodeNames {
+			if node, found := byName[name]; found {
+				filtered = append(filtered, node)
+			}
+		}
+		return filtered, result.FailedNodes, nil
+	}
+	if result.Nodes != nil {
+		filtered := make([]*apiv1.Node, len(result.Nodes.Items))
+		for i := range result.Nodes.Items {
+			filtered[i] = &result.Nodes.Items[i]
+		}
+		return filtered, result.FailedNodes, nil
+	}
+	// Neither Nodes nor NodeNames was set: the extender didn't tell us which
+	// nodes passed. Treat that as "none of them did" rather than defaulting
+	// to the full input set, so a response that only carries FailedNodes
+	// can never be read as every node having passed.
+	return nil, result.FailedNodes, nil
+}
+
+func (e *httpExtender) Bind(pod *apiv1.Pod, node *apiv1.Node) error {
+	if !e.SupportsBind() {
+		return fmt.Errorf("extender %s does not support binding", e.Name())
+	}
+	req := &extenderBindingRequest{
+		PodName:      pod.Name,
+		PodNamespace: pod.Namespace,
+		Node:         node.Name,
+	}
+	result := &extenderBindingResult{}
+	if err := e.send(e.config.BindVerb, req, result); err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return fmt.Errorf("extender %s returned an error on bind: %s", e.Name(), result.Error)
+	}
+	return nil
+}
+
+func (e *httpExtender) send(verb string, args interface{}, result interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	url := e.config.URLPrefix + verb
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("extender %s returned HTTP status %v", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
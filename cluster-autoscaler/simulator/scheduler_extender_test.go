@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHTTPExtenderFilterAmbiguousResponseRejects makes sure that a filter
+// response carrying neither "nodes" nor "nodenames" - just "failedNodes" - is
+// never read as every candidate having passed.
+func TestHTTPExtenderFilterAmbiguousResponseRejects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"failedNodes":{"node":"rejected"}}`))
+	}))
+	defer server.Close()
+
+	extender := NewHTTPExtender(ExtenderConfig{
+		URLPrefix:   server.URL + "/",
+		FilterVerb:  "filter",
+		HTTPTimeout: 5 * time.Second,
+	})
+
+	node := BuildTestNode("node", 10, 100)
+	pod := BuildTestPod("pod", 1, 1)
+
+	filtered, failedNodes, err := extender.Filter(pod, []*apiv1.Node{node})
+	assert.NoError(t, err)
+	assert.Empty(t, filtered)
+	assert.Equal(t, "rejected", failedNodes["node"])
+}
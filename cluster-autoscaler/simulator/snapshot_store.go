@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// snapshotMagic identifies the start of a ClusterSnapshot dump, so that Load
+// can fail fast on unrelated input instead of decoding garbage.
+const snapshotMagic = "CASNAP"
+
+// snapshotSchemaVersion is bumped whenever the on-disk layout changes.
+const snapshotSchemaVersion = uint32(1)
+
+var snapshotSerializer = protobuf.NewSerializer(scheme.Scheme, scheme.Scheme)
+
+// SnapshotStore saves and restores the base state of a ClusterSnapshot - every
+// node, every pod, and their assignments - independent of a live cluster.
+// This is meant for deterministic bug reports: users can dump the exact
+// cluster state that produced a bad scale-up decision, attach it to an issue,
+// and maintainers can `go test` against it.
+type SnapshotStore struct{}
+
+// Save writes every node and pod currently visible in snapshot to w, using a
+// small self-describing and versioned format: a magic + schema version
+// header, followed by a length-prefixed, protobuf-encoded v1.Node per node
+// and v1.Pod per pod, using the standard Kubernetes codec.
+func (SnapshotStore) Save(w io.Writer, snapshot ClusterSnapshot) error {
+	nodeInfos, err := snapshot.NodeInfos().List()
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	pods, err := snapshot.Pods().List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	if _, err := io.WriteString(w, snapshotMagic); err != nil {
+		return err
+	}
+	if err := writeUint32(w, snapshotSchemaVersion); err != nil {
+		return err
+	}
+
+	if err := writeUint32(w, uint32(len(nodeInfos))); err != nil {
+		return err
+	}
+	for _, nodeInfo := range nodeInfos {
+		if err := writeObject(w, nodeInfo.Node()); err != nil {
+			return fmt.Errorf("failed to write node %s: %v", nodeInfo.Node().Name, err)
+		}
+	}
+
+	if err := writeUint32(w, uint32(len(pods))); err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if err := writeObject(w, pod); err != nil {
+			return fmt.Errorf("failed to write pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load reads a snapshot previously written by Save from r, reconstructing it
+// via newSnapshot (e.g. NewBasicClusterSnapshot or NewDeltaClusterSnapshot) so
+// that the per-node NodeInfo caches of the loaded snapshot are built exactly
+// as if the nodes and pods had been added one by one.
+func (SnapshotStore) Load(r io.Reader, newSnapshot func() ClusterSnapshot) (ClusterSnapshot, error) {
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read magic: %v", err)
+	}
+	if string(magic) != snapshotMagic {
+		return nil, fmt.Errorf("not a ClusterSnapshot dump: bad magic %q", magic)
+	}
+	version, err := readUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if version != snapshotSchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d", version)
+	}
+
+	snapshot := newSnapshot()
+
+	nodeCount, err := readUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node count: %v", err)
+	}
+	for i := uint32(0); i < nodeCount; i++ {
+		node := &apiv1.Node{}
+		if err := readObject(r, node); err != nil {
+			return nil, fmt.Errorf("failed to read node %d: %v", i, err)
+		}
+		if err := snapshot.AddNode(node); err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %v", node.Name, err)
+		}
+	}
+
+	podCount, err := readUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod count: %v", err)
+	}
+	for i := uint32(0); i < podCount; i++ {
+		pod := &apiv1.Pod{}
+		if err := readObject(r, pod); err != nil {
+			return nil, fmt.Errorf("failed to read pod %d: %v", i, err)
+		}
+		if err := snapshot.AddPod(pod, pod.Spec.NodeName); err != nil {
+			return nil, fmt.Errorf("failed to add pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return snapshot, nil
+}
+
+func writeUint32(w io.Writer, v uint32) error {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+func writeObject(w io.Writer, obj runtime.Object) error {
+	var buf bytes.Buffer
+	if err := snapshotSerializer.Encode(obj, &buf); err != nil {
+		return err
+	}
+	if err := writeUint32(w, uint32(buf.Len())); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readObject(r io.Reader, into runtime.Object) error {
+	length, err := readUint32(r)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	_, _, err = snapshotSerializer.Decode(data, nil, into)
+	return err
+}
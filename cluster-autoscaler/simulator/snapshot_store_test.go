@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// podFingerprint captures the pod fields a Save/Load round-trip must
+// preserve, normalizing resource.Quantity via String() rather than comparing
+// *apiv1.Pod objects directly: a protobuf round-trip populates Quantity's
+// unexported cached-string field differently than BuildTestPod does, so
+// reflect.DeepEqual on the raw objects would spuriously fail even though the
+// pods are equivalent.
+type podFingerprint struct {
+	key      string
+	nodeName string
+	labels   map[string]string
+	requests map[string]string
+}
+
+func fingerprintPod(pod *apiv1.Pod) podFingerprint {
+	requests := map[string]string{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			requests[string(name)] = quantity.String()
+		}
+	}
+	return podFingerprint{
+		key:      pod.Namespace + "/" + pod.Name,
+		nodeName: pod.Spec.NodeName,
+		labels:   pod.Labels,
+		requests: requests,
+	}
+}
+
+func fingerprintPods(pods []*apiv1.Pod) []podFingerprint {
+	fingerprints := make([]podFingerprint, len(pods))
+	for i, pod := range pods {
+		fingerprints[i] = fingerprintPod(pod)
+	}
+	return fingerprints
+}
+
+// nodeFingerprint captures the node fields a Save/Load round-trip must
+// preserve, for the same reason podFingerprint exists.
+type nodeFingerprint struct {
+	name        string
+	allocatable map[string]string
+}
+
+func fingerprintNode(node *apiv1.Node) nodeFingerprint {
+	allocatable := map[string]string{}
+	for name, quantity := range node.Status.Allocatable {
+		allocatable[string(name)] = quantity.String()
+	}
+	return nodeFingerprint{name: node.Name, allocatable: allocatable}
+}
+
+func fingerprintNodes(nodes []*apiv1.Node) []nodeFingerprint {
+	fingerprints := make([]nodeFingerprint, len(nodes))
+	for i, node := range nodes {
+		fingerprints[i] = fingerprintNode(node)
+	}
+	return fingerprints
+}
+
+func TestSnapshotStoreRoundTrip(t *testing.T) {
+	nodeCount := 3
+	podCount := 9
+
+	nodes := createTestNodes(nodeCount)
+	pods := createTestPods(podCount)
+	assignPodsToNodes(pods, nodes)
+
+	for name, snapshotFactory := range snapshots {
+		t.Run(fmt.Sprintf("%s: save, load, list yields element-matched nodes and pods", name),
+			func(t *testing.T) {
+				snapshot := snapshotFactory()
+				err := snapshot.AddNodes(nodes)
+				assert.NoError(t, err)
+				for _, pod := range pods {
+					err = snapshot.AddPod(pod, pod.Spec.NodeName)
+					assert.NoError(t, err)
+				}
+
+				var buf bytes.Buffer
+				err = (SnapshotStore{}).Save(&buf, snapshot)
+				assert.NoError(t, err)
+
+				loaded, err := (SnapshotStore{}).Load(&buf, snapshotFactory)
+				assert.NoError(t, err)
+
+				loadedNodes, err := loaded.NodeInfos().List()
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, nodeNames(nodes), nodeInfoNames(loadedNodes))
+				assert.ElementsMatch(t, fingerprintNodes(nodes), fingerprintNodes(extractNodes(loadedNodes)))
+
+				loadedPods, err := loaded.Pods().List(labels.Everything())
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, fingerprintPods(pods), fingerprintPods(loadedPods))
+
+				// Fork/commit must keep working on a loaded snapshot.
+				err = loaded.Fork()
+				assert.NoError(t, err)
+				extra := createTestNodesWithPrefix("loaded-extra", 1)[0]
+				err = loaded.AddNode(extra)
+				assert.NoError(t, err)
+				err = loaded.Commit()
+				assert.NoError(t, err)
+
+				afterCommit, err := loaded.NodeInfos().List()
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, append(nodeNames(nodes), extra.Name), nodeInfoNames(afterCommit))
+			})
+	}
+}
+
+func TestSnapshotStoreLoadRejectsBadMagic(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("garbage")
+	_, err := (SnapshotStore{}).Load(&buf, func() ClusterSnapshot { return NewBasicClusterSnapshot() })
+	assert.Error(t, err)
+}
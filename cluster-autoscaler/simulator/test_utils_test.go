@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func createTestNodes(count int) []*apiv1.Node {
+	return createTestNodesWithPrefix("n", count)
+}
+
+func createTestNodesWithPrefix(prefix string, count int) []*apiv1.Node {
+	nodes := make([]*apiv1.Node, count, count)
+	for i := 0; i < count; i++ {
+		nodes[i] = BuildTestNode(fmt.Sprintf("%s%d", prefix, i), 2000, 2000000)
+	}
+	return nodes
+}
+
+func createTestPods(count int) []*apiv1.Pod {
+	pods := make([]*apiv1.Pod, count, count)
+	for i := 0; i < count; i++ {
+		pods[i] = BuildTestPod(fmt.Sprintf("p%d", i), 100, 100)
+	}
+	return pods
+}
+
+// assignPodsToNodes distributes pods evenly across nodes, round-robin.
+func assignPodsToNodes(pods []*apiv1.Pod, nodes []*apiv1.Node) {
+	if len(nodes) == 0 {
+		return
+	}
+	for i, pod := range pods {
+		pod.Spec.NodeName = nodes[i%len(nodes)].Name
+	}
+}